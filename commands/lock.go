@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/httputil"
+	"github.com/github/git-lfs/locking"
+	"github.com/github/git-lfs/tools"
+	"github.com/spf13/cobra"
+)
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Set a file as \"locked\" on the lock server",
+	Run:   lockCommand,
+}
+
+type lockRequest struct {
+	Path string `json:"path"`
+}
+
+type lockResponse struct {
+	Lock struct {
+		ID    string `json:"id"`
+		Path  string `json:"path"`
+		Owner struct {
+			Name string `json:"name"`
+		} `json:"owner"`
+	} `json:"lock"`
+	Message string `json:"message"`
+}
+
+// lockCommand asks the server to lock path, then commits the result to the
+// local lock cache (locking.CommitLock, guarded by the cross-process repo
+// lock) so IsFileLockedByCurrentCommitter reflects it without another
+// server round trip, and makes the file writable immediately.
+func lockCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		Exit("Usage: git lfs lock <path>")
+	}
+	path := args[0]
+
+	body, err := json.Marshal(lockRequest{Path: path})
+	if err != nil {
+		Exit("Error encoding lock request: %s", err)
+	}
+
+	req, err := http.NewRequest("POST", config.Config.Endpoint("upload")+"/locks", bytes.NewReader(body))
+	if err != nil {
+		Exit("Error building lock request: %s", err)
+	}
+	req.Header.Set("Accept", "application/vnd.git-lfs+json")
+	req.Header.Set("Content-Type", "application/vnd.git-lfs+json")
+
+	resp, err := httputil.DoHttpRequest(req, true)
+	if err != nil {
+		Exit("Error requesting lock on %q: %s", path, err)
+	}
+	defer resp.Body.Close()
+
+	var lockResp lockResponse
+	if err := json.NewDecoder(resp.Body).Decode(&lockResp); err != nil {
+		Exit("Error decoding lock response: %s", err)
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		Exit("Server rejected lock on %q: %s", path, lockResp.Message)
+	}
+
+	l := locking.Lock{ID: lockResp.Lock.ID, Path: lockResp.Lock.Path, Owner: lockResp.Lock.Owner.Name}
+	if err := locking.CommitLock(l); err != nil {
+		Exit("Error updating local lock cache: %s", err)
+	}
+
+	if err := tools.SetFileWriteFlag(path, true); err != nil {
+		Exit("Error making %q writable: %s", path, err)
+	}
+
+	fmt.Printf("Locked %s\n", path)
+}
+
+func init() {
+	RootCmd.AddCommand(lockCmd)
+}
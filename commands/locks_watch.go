@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/github/git-lfs/locking"
+	"github.com/spf13/cobra"
+)
+
+var locksCmdWatch bool
+
+// watchLocksCommand runs when `git lfs locks --watch` is invoked. It blocks
+// until interrupted, keeping lockable file write flags in sync with both
+// local filesystem changes and the server's lock state.
+func watchLocksCommand(cmd *cobra.Command, args []string) {
+	if !locksCmdWatch {
+		locksCommand(cmd, args)
+		return
+	}
+
+	if err := locking.Watch(context.Background()); err != nil {
+		Exit("Error watching locks: %s", err)
+	}
+}
+
+func init() {
+	locksCmd.Run = watchLocksCommand
+	locksCmd.Flags().BoolVar(&locksCmdWatch, "watch", false, "keep lockable file write flags in sync with the working tree and the server until interrupted")
+}
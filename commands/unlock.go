@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/httputil"
+	"github.com/github/git-lfs/locking"
+	"github.com/github/git-lfs/tools"
+	"github.com/spf13/cobra"
+)
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Remove a \"locked\" setting for a file on the lock server",
+	Run:   unlockCommand,
+}
+
+// unlockCommand asks the server to release the lock on path, then clears it
+// from the local lock cache (locking.ClearCachedLock, guarded by the
+// cross-process repo lock) and puts the file back to read-only.
+func unlockCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		Exit("Usage: git lfs unlock <path>")
+	}
+	path := args[0]
+
+	lockID, err := locking.LockIDForPath(path)
+	if err != nil {
+		Exit("Error finding lock id for %q: %s", path, err)
+	}
+
+	req, err := http.NewRequest("POST", config.Config.Endpoint("upload")+"/locks/"+lockID+"/unlock", nil)
+	if err != nil {
+		Exit("Error building unlock request: %s", err)
+	}
+	req.Header.Set("Accept", "application/vnd.git-lfs+json")
+
+	resp, err := httputil.DoHttpRequest(req, true)
+	if err != nil {
+		Exit("Error requesting unlock of %q: %s", path, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		Exit("Server rejected unlock of %q (status %d)", path, resp.StatusCode)
+	}
+
+	if err := locking.ClearCachedLock(path); err != nil {
+		Exit("Error updating local lock cache: %s", err)
+	}
+
+	if err := tools.SetFileWriteFlag(path, false); err != nil {
+		Exit("Error making %q read-only: %s", path, err)
+	}
+
+	fmt.Printf("Unlocked %s\n", path)
+}
+
+func init() {
+	RootCmd.AddCommand(unlockCmd)
+}
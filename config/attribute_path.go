@@ -0,0 +1,36 @@
+package config
+
+import (
+	"sync"
+
+	"github.com/github/git-lfs/locking/pathspec"
+)
+
+// attributePathMatchers caches the compiled pathspec.Pattern for each
+// AttributePath. It's a sync.Map rather than a map guarded by a single
+// mutex so that looking up an already-compiled matcher (the common case,
+// once the cache is warm) doesn't serialize concurrent callers against
+// each other.
+var attributePathMatchers sync.Map // map[*AttributePath]*pathspec.Pattern
+
+// Matcher returns the compiled pathspec.Pattern for p.Path, compiling and
+// caching it the first time it's asked for so repeated matches against many
+// candidate paths (eg during a repo-wide lockable scan) don't pay the
+// compile cost more than once per AttributePath. The cache is keyed by p's
+// address, so a cached matcher is only reused while its text still equals
+// p.Path; otherwise it is recompiled and replaced.
+func (p *AttributePath) Matcher() (*pathspec.Pattern, error) {
+	if m, ok := attributePathMatchers.Load(p); ok {
+		if pat := m.(*pathspec.Pattern); pat.String() == p.Path {
+			return pat, nil
+		}
+	}
+
+	m, err := pathspec.Compile(p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	attributePathMatchers.Store(p, m)
+	return m, nil
+}
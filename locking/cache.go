@@ -0,0 +1,131 @@
+package locking
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/github/git-lfs/config"
+)
+
+// Lock is a single lock recorded in the local lock cache, populated from
+// the server's response to a `git lfs lock`/`unlock` request so that later
+// write-flag checks don't need a server round trip.
+type Lock struct {
+	Path  string `json:"path"`
+	ID    string `json:"id"`
+	Owner string `json:"owner"`
+}
+
+// lockCachePath returns the path of the local cache of locks the server has
+// told this repository about.
+func lockCachePath() string {
+	return filepath.Join(config.LocalWorkingDir, ".git", "lfs", "locks", "cache.json")
+}
+
+// readCachedLocks loads the local lock cache, returning an empty slice if it
+// doesn't exist yet.
+func readCachedLocks() ([]Lock, error) {
+	f, err := os.Open(lockCachePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var locks []Lock
+	if err := json.NewDecoder(f).Decode(&locks); err != nil {
+		return nil, err
+	}
+	return locks, nil
+}
+
+// writeCachedLocks overwrites the local lock cache with locks.
+func writeCachedLocks(locks []Lock) error {
+	path := lockCachePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(locks)
+}
+
+// CommitLock records a lock the server has just granted in the local cache.
+// It's held under the cross-process repo lock so that two `git lfs lock`
+// invocations running concurrently against the same working tree can't
+// interleave their read-modify-write of the cache and lose one another's
+// update.
+func CommitLock(l Lock) error {
+	return WithRepoLock(func() error {
+		locks, err := readCachedLocks()
+		if err != nil {
+			return err
+		}
+		for _, existing := range locks {
+			if existing.Path == l.Path {
+				return nil
+			}
+		}
+		return writeCachedLocks(append(locks, l))
+	})
+}
+
+// ClearCachedLock removes path's entry from the local lock cache after the
+// server has confirmed the unlock. It's held under the same cross-process
+// repo lock as CommitLock for the same reason.
+func ClearCachedLock(path string) error {
+	return WithRepoLock(func() error {
+		locks, err := readCachedLocks()
+		if err != nil {
+			return err
+		}
+
+		kept := locks[:0]
+		for _, existing := range locks {
+			if existing.Path != path {
+				kept = append(kept, existing)
+			}
+		}
+		return writeCachedLocks(kept)
+	})
+}
+
+// IsFileLockedByCurrentCommitter returns whether path is locked by the
+// current committer, according to the local lock cache.
+func IsFileLockedByCurrentCommitter(path string) bool {
+	locks, err := readCachedLocks()
+	if err != nil {
+		return false
+	}
+	for _, l := range locks {
+		if l.Path == path {
+			return l.Owner == config.CurrentCommitter()
+		}
+	}
+	return false
+}
+
+// LockIDForPath returns the server-assigned lock id cached for path, as
+// recorded by a prior CommitLock, so `git lfs unlock` doesn't need a
+// separate lookup call before it can release the lock.
+func LockIDForPath(path string) (string, error) {
+	locks, err := readCachedLocks()
+	if err != nil {
+		return "", err
+	}
+	for _, l := range locks {
+		if l.Path == path {
+			return l.ID, nil
+		}
+	}
+	return "", fmt.Errorf("locking: no cached lock found for %q", path)
+}
@@ -1,52 +1,91 @@
 package locking
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"runtime"
 	"strings"
 	"sync"
 
+	"github.com/github/git-lfs/progress"
 	"github.com/github/git-lfs/tools"
 
 	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/locking/pathspec"
 )
 
+// lockableDirScanChannelSize bounds how many pending file paths can be
+// queued for the worker pool in FixLockableFileWriteFlagsInDir before the
+// walker blocks, so a very large directory tree doesn't buffer unboundedly.
+const lockableDirScanChannelSize = 128
+
+// lockableAttr pairs a lockable config.AttributePath with its matcher,
+// compiled once when the cache below is populated. IsFileLockable runs
+// concurrently from the FixLockableFileWriteFlagsInDir worker pool, so
+// precompiling here means that hot path never has to go through
+// AttributePath.Matcher()'s cache for every file.
+type lockableAttr struct {
+	path    string
+	matcher *pathspec.Pattern
+}
+
 var (
-	// lockable patterns from .gitattributes
-	cachedLockablePatterns []string
-	cachedLockableMutex    sync.Mutex
+	// lockable attribute paths from .gitattributes, precompiled
+	cachedLockableAttrs []lockableAttr
+	cachedLockableMutex sync.Mutex
 )
 
 // GetLockablePatterns returns a list of patterns in .gitattributes which are
 // marked as lockable
 func GetLockablePatterns() []string {
+	attrs := lockableAttrs()
+	patterns := make([]string, len(attrs))
+	for i, a := range attrs {
+		patterns[i] = a.path
+	}
+	return patterns
+}
+
+// lockableAttrs returns the cached, Lockable-filtered, precompiled attribute
+// paths, populating the cache from config.GetAttributePaths() if necessary.
+func lockableAttrs() []lockableAttr {
 	cachedLockableMutex.Lock()
 	defer cachedLockableMutex.Unlock()
 
 	// Only load once
-	if cachedLockablePatterns == nil {
+	if cachedLockableAttrs == nil {
 		// Always make non-nil even if empty
-		cachedLockablePatterns = make([]string, 0, 10)
+		cachedLockableAttrs = make([]lockableAttr, 0, 10)
 
-		paths := config.GetAttributePaths()
-		for _, p := range paths {
-			if p.Lockable {
-				cachedLockablePatterns = append(cachedLockablePatterns, p.Path)
+		for _, p := range config.GetAttributePaths() {
+			if !p.Lockable {
+				continue
+			}
+			matcher, err := p.Matcher()
+			if err != nil {
+				// Ignore patterns we can't parse rather than failing the whole scan
+				continue
 			}
+			cachedLockableAttrs = append(cachedLockableAttrs, lockableAttr{path: p.Path, matcher: matcher})
 		}
 	}
 
-	return cachedLockablePatterns
-
+	return cachedLockableAttrs
 }
 
-// RefreshLockablePatterns causes us to re-read the .gitattributes and caches the result
-func RefreshLockablePatterns() {
-	cachedLockableMutex.Lock()
-	defer cachedLockableMutex.Unlock()
-	cachedLockablePatterns = nil
+// RefreshLockablePatterns causes us to re-read the .gitattributes and caches the result.
+// It runs under the cross-process repo lock, so it waits for any lock-state
+// mutation another git-lfs process is making to finish. The cache itself is
+// in-process memory and is not shared with other processes.
+func RefreshLockablePatterns() error {
+	return WithRepoLock(func() error {
+		cachedLockableMutex.Lock()
+		defer cachedLockableMutex.Unlock()
+		cachedLockableAttrs = nil
+		return nil
+	})
 }
 
 // IsFileLockable returns whether a specific file path is marked as Lockable,
@@ -54,15 +93,8 @@ func RefreshLockablePatterns() {
 // Lockable patterns are cached once for performance, unless you call RefreshLockablePatterns
 // path should be relative to repository root
 func IsFileLockable(path string) bool {
-	patterns := GetLockablePatterns()
-	for _, wildcard := range patterns {
-		// Convert wildcards to regex
-		regStr := "^" + regexp.QuoteMeta(wildcard)
-		regStr = strings.Replace(regStr, "\\*", ".*", -1)
-		regStr = strings.Replace(regStr, "\\?", ".", -1)
-		reg := regexp.MustCompile(regStr)
-
-		if reg.MatchString(path) {
+	for _, a := range lockableAttrs() {
+		if a.matcher.Match(path, false) {
 			return true
 		}
 	}
@@ -77,7 +109,28 @@ func IsFileLockable(path string) bool {
 // This function can be used after a clone or checkout to ensure that file
 // state correctly reflects the locking state
 func FixAllLockableFileWriteFlags() error {
-	return FixLockableFileWriteFlagsInDir("", true)
+	return FixAllLockableFileWriteFlagsWithContext(context.Background())
+}
+
+// FixAllLockableFileWriteFlagsWithContext behaves like
+// FixAllLockableFileWriteFlags, but aborts the scan if ctx is cancelled, eg
+// because the user hit Ctrl-C during a `git lfs checkout`. The error it then
+// returns includes ctx.Err() alongside any per-file errors found before the
+// cancellation; it is not ctx.Err() itself.
+func FixAllLockableFileWriteFlagsWithContext(ctx context.Context) error {
+	return WithRepoLock(func() error {
+		return FixLockableFileWriteFlagsInDir(ctx, "", true)
+	})
+}
+
+// lockableWorkerCount returns how many goroutines FixLockableFileWriteFlagsInDir
+// uses to stat/chmod files concurrently, taken from the "lfs.lockableworkers"
+// git config, or runtime.NumCPU() if that isn't set.
+func lockableWorkerCount() int {
+	if n := config.Config.Int("lfs.lockableworkers", 0); n > 0 {
+		return n
+	}
+	return runtime.NumCPU()
 }
 
 // FixLockableFileWriteFlagsInDir scans dir (which can either be a relative dir
@@ -85,7 +138,14 @@ func FixAllLockableFileWriteFlags() error {
 // files which are lockable, and makes sure their write flags are set correctly
 // based on whether they are currently locked or unlocked. Files which are
 // unlocked are made read-only, files which are locked are made writeable.
-func FixLockableFileWriteFlagsInDir(dir string, recursive bool) error {
+//
+// The scan itself walks the tree on a single goroutine, but the
+// stat/IsFileLockable/chmod work for each file is farmed out to a bounded
+// pool of workers (see lockableWorkerCount), since on SSDs and network
+// filesystems that work is what dominates wall clock time on large trees. A
+// single file's error doesn't abort the scan; all errors encountered are
+// returned together. ctx can be used to cancel a scan in progress.
+func FixLockableFileWriteFlagsInDir(ctx context.Context, dir string, recursive bool) error {
 	absPath := dir
 	if !filepath.IsAbs(dir) {
 		absPath = filepath.Join(config.LocalWorkingDir, dir)
@@ -98,23 +158,71 @@ func FixLockableFileWriteFlagsInDir(dir string, recursive bool) error {
 		return fmt.Errorf("%q is not a valid directory", dir)
 	}
 
-	// For simplicity, don't use goroutines to parallelise recursive scan
-	// This routine is almost certainly disk-limited anyway
-	// We don't need sorting so don't use ioutil.Readdir or filepath.Walk
+	paths := make(chan string, lockableDirScanChannelSize)
+	errs := new(multiError)
+
+	meter := progress.NewMeter()
+	meter.Start()
+	defer meter.Finish()
+
+	var wg sync.WaitGroup
+	numWorkers := lockableWorkerCount()
+	wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			defer wg.Done()
+			for relpath := range paths {
+				if IsFileLockable(relpath) {
+					locked := IsFileLockedByCurrentCommitter(relpath)
+					if err := tools.SetFileWriteFlag(relpath, locked); err != nil {
+						errs.Add(err)
+					}
+				}
+				meter.Add(1)
+			}
+		}()
+	}
+
+	walkErr := walkLockableDir(ctx, absPath, recursive, paths, errs)
+	close(paths)
+	wg.Wait()
+
+	errs.Add(walkErr)
+	return errs.ErrorOrNil()
+}
+
+// walkLockableDir descends absPath, recursing into subdirectories when
+// recursive is true, sending the repo-root-relative, forward-slash path of
+// every file it finds to paths. A directory it can't open or read (eg a
+// permission-restricted subtree) is recorded in errs and skipped, so the
+// rest of the tree still gets walked. It only returns an error - ctx.Err() -
+// when ctx is cancelled, which aborts the whole walk.
+func walkLockableDir(ctx context.Context, absPath string, recursive bool, paths chan<- string, errs *multiError) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
 	d, err := os.Open(absPath)
 	if err != nil {
-		return err
+		errs.Add(err)
+		return nil
 	}
-
 	contents, err := d.Readdir(-1)
+	d.Close()
 	if err != nil {
-		return err
+		errs.Add(err)
+		return nil
 	}
+
 	for _, fi := range contents {
 		abschild := filepath.Join(absPath, fi.Name())
 		if fi.IsDir() {
 			if recursive {
-				err = FixLockableFileWriteFlagsInDir(abschild, recursive)
+				if err := walkLockableDir(ctx, abschild, recursive, paths, errs); err != nil {
+					return err
+				}
 			}
 			continue
 		}
@@ -122,20 +230,20 @@ func FixLockableFileWriteFlagsInDir(dir string, recursive bool) error {
 		// This is a file, get relative to repo root
 		relpath, err := filepath.Rel(config.LocalWorkingDir, abschild)
 		if err != nil {
-			return err
+			errs.Add(err)
+			continue
 		}
 		// Convert to git-style forward slash separators if necessary
 		// Necessary to match attributes
 		if filepath.Separator == '\\' {
 			relpath = strings.Replace(relpath, "\\", "/", -1)
 		}
-		if IsFileLockable(relpath) {
-			err = tools.SetFileWriteFlag(relpath, IsFileLockedByCurrentCommitter(relpath))
-			if err != nil {
-				return err
-			}
-		}
 
+		select {
+		case paths <- relpath:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 	return nil
 }
@@ -0,0 +1,58 @@
+package locking
+
+import (
+	"bytes"
+	"sync"
+)
+
+// multiError collects errors reported from multiple goroutines into a
+// single value. It is safe for concurrent use.
+type multiError struct {
+	mu     sync.Mutex
+	errors []error
+}
+
+// Add appends err to the list of accumulated errors. A nil err is ignored.
+func (m *multiError) Add(err error) {
+	if err == nil {
+		return
+	}
+	m.mu.Lock()
+	m.errors = append(m.errors, err)
+	m.mu.Unlock()
+}
+
+// ErrorOrNil returns m as an error if any errors were added, or nil
+// otherwise.
+func (m *multiError) ErrorOrNil() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.errors) == 0 {
+		return nil
+	}
+	return &multiErrorResult{errors: m.errors}
+}
+
+// multiErrorResult is the immutable error value returned by
+// multiError.ErrorOrNil.
+type multiErrorResult struct {
+	errors []error
+}
+
+func (m *multiErrorResult) Error() string {
+	var buf bytes.Buffer
+	if len(m.errors) == 1 {
+		return m.errors[0].Error()
+	}
+	buf.WriteString("multiple errors occurred:")
+	for _, err := range m.errors {
+		buf.WriteString("\n\t* ")
+		buf.WriteString(err.Error())
+	}
+	return buf.String()
+}
+
+// Errors returns the individual errors that were accumulated.
+func (m *multiErrorResult) Errors() []error {
+	return m.errors
+}
@@ -0,0 +1,135 @@
+// Package pathspec implements the pattern matching rules used by
+// .gitattributes (see gitattributes(5) and gitignore(5)).
+//
+// The rules implemented are:
+//   - "*" matches any sequence of characters except "/"
+//   - "**" matches across directory boundaries, including zero directories
+//   - "?" matches any single character except "/"
+//   - "[abc]" and "[!abc]" character classes
+//   - a leading "/" anchors the pattern to the repository root
+//   - a trailing "/" matches directories only
+//   - a pattern containing no "/" (other than a trailing one) matches at
+//     any depth in the tree
+package pathspec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a single compiled gitattributes-style pattern. It is safe for
+// concurrent use.
+type Pattern struct {
+	raw     string
+	regex   *regexp.Regexp
+	dirOnly bool
+}
+
+// Compile parses a single gitattributes pattern and returns a Pattern that
+// can be matched repeatedly without re-parsing.
+func Compile(pattern string) (*Pattern, error) {
+	if len(pattern) == 0 {
+		return nil, fmt.Errorf("pathspec: empty pattern")
+	}
+
+	raw := pattern
+
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	anchored = anchored || strings.Contains(pattern, "/")
+
+	body, err := translate(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var regStr string
+	if anchored {
+		regStr = "^" + body + "$"
+	} else {
+		regStr = "(?:^|.*/)" + body + "$"
+	}
+
+	reg, err := regexp.Compile(regStr)
+	if err != nil {
+		return nil, fmt.Errorf("pathspec: invalid pattern %q: %v", raw, err)
+	}
+
+	return &Pattern{raw: raw, regex: reg, dirOnly: dirOnly}, nil
+}
+
+// Match reports whether path (relative to the repository root, using "/" as
+// the separator) matches the pattern. isDir indicates whether path refers to
+// a directory; patterns with a trailing "/" only ever match directories.
+func (p *Pattern) Match(path string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	return p.regex.MatchString(path)
+}
+
+// String returns the original, uncompiled pattern text.
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+// translate converts the non-anchoring, non-directory part of a
+// gitattributes pattern into the body of a regular expression.
+func translate(pattern string) (string, error) {
+	var out strings.Builder
+	runes := []rune(pattern)
+
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; c {
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				j := i
+				for j < len(runes) && runes[j] == '*' {
+					j++
+				}
+				if j < len(runes) && runes[j] == '/' {
+					// "**/" matches zero or more whole path segments
+					out.WriteString("(?:.*/)?")
+					j++
+				} else {
+					// "**" elsewhere matches anything, including "/"
+					out.WriteString(".*")
+				}
+				i = j - 1
+			} else {
+				out.WriteString("[^/]*")
+			}
+		case '?':
+			out.WriteString("[^/]")
+		case '[':
+			j := i + 1
+			if j < len(runes) && (runes[j] == '!' || runes[j] == '^') {
+				j++
+			}
+			if j < len(runes) && runes[j] == ']' {
+				j++
+			}
+			for j < len(runes) && runes[j] != ']' {
+				j++
+			}
+			if j >= len(runes) {
+				return "", fmt.Errorf("pathspec: unterminated character class in %q", pattern)
+			}
+			class := string(runes[i+1 : j])
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			out.WriteString("[" + class + "]")
+			i = j
+		default:
+			out.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return out.String(), nil
+}
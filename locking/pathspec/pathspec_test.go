@@ -0,0 +1,63 @@
+package pathspec
+
+import "testing"
+
+func TestPatternMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		// "*" matches within a single path segment only
+		{"*.psd", "image.psd", false, true},
+
+		// no-slash patterns match at any depth; "*" still only matches
+		// within the final path segment it's anchored against
+		{"*.bin", "assets/models/character.bin", false, true},
+		{"*.psd", "art/image.psd", false, true},
+
+		// leading "/" anchors to the repository root
+		{"/assets/*.bin", "assets/character.bin", false, true},
+		{"/assets/*.bin", "other/assets/character.bin", false, false},
+
+		// "**" matches across directories
+		{"docs/**/*.psd", "docs/a/b/image.psd", false, true},
+		{"docs/**/*.psd", "docs/image.psd", false, true},
+		{"docs/**/*.psd", "other/docs/a/image.psd", false, false},
+
+		// trailing "/" matches directories only
+		{"assets/", "assets", true, true},
+		{"assets/", "assets", false, false},
+
+		// character classes
+		{"*.[pP][sS][dD]", "image.PSD", false, true},
+		{"*.[pP][sS][dD]", "image.txt", false, false},
+
+		// "?" matches exactly one character, never "/"
+		{"a?.bin", "ab.bin", false, true},
+		{"a?.bin", "a/b.bin", false, false},
+	}
+
+	for _, c := range cases {
+		p, err := Compile(c.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q) returned error: %v", c.pattern, err)
+		}
+		if got := p.Match(c.path, c.isDir); got != c.want {
+			t.Errorf("Pattern(%q).Match(%q, isDir=%v) = %v, want %v", c.pattern, c.path, c.isDir, got, c.want)
+		}
+	}
+}
+
+func TestCompileEmptyPattern(t *testing.T) {
+	if _, err := Compile(""); err == nil {
+		t.Fatal("Compile(\"\") expected an error, got nil")
+	}
+}
+
+func TestCompileUnterminatedCharacterClass(t *testing.T) {
+	if _, err := Compile("*.[abc"); err == nil {
+		t.Fatal("Compile(\"*.[abc\") expected an error, got nil")
+	}
+}
@@ -0,0 +1,96 @@
+package locking
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/httputil"
+	"github.com/github/git-lfs/tools"
+)
+
+// remoteLock is the subset of the LFS Locking API's lock resource that
+// Watch needs to decide whether a file should be writable.
+type remoteLock struct {
+	Path  string `json:"path"`
+	Owner struct {
+		Name string `json:"name"`
+	} `json:"owner"`
+}
+
+// listLocksResponse mirrors the JSON body of the LFS Locking API's
+// "list locks" endpoint (GET <endpoint>/locks).
+type listLocksResponse struct {
+	Locks []remoteLock `json:"locks"`
+}
+
+// listRemoteLocks asks the configured LFS server which paths are currently
+// locked and by whom, using the same authenticated client (and credential
+// helper negotiation) as the rest of git-lfs's API calls.
+func listRemoteLocks(ctx context.Context) ([]remoteLock, error) {
+	endpoint := config.Config.Endpoint("download")
+
+	req, err := httputil.NewHttpRequest("GET", endpoint+"/locks", map[string]string{
+		"Accept": "application/vnd.git-lfs+json",
+	})
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := httputil.DoHttpRequest(req, true)
+	if err != nil {
+		return nil, fmt.Errorf("locking: could not list locks: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("locking: server returned %d listing locks", resp.StatusCode)
+	}
+
+	var listing listLocksResponse
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return nil, fmt.Errorf("locking: could not parse lock listing: %v", err)
+	}
+
+	return listing.Locks, nil
+}
+
+// applyRemoteLocks reconciles local write flags against the server's lock
+// listing. Any path in locks gets its write flag set based on whether the
+// current committer holds that lock; any path in previouslyLocked that has
+// since dropped off the listing (unlocked by us or by someone else) is
+// flipped back to read-only. It returns the new set of locked paths, to
+// pass back in as previouslyLocked on the next call.
+//
+// It's held under the repo lock so it can't race a concurrent checkout, and
+// a single file's SetFileWriteFlag error doesn't stop the rest of the batch
+// from being applied - all errors are collected and returned together.
+func applyRemoteLocks(locks []remoteLock, previouslyLocked map[string]bool) (map[string]bool, error) {
+	nowLocked := make(map[string]bool, len(locks))
+	errs := new(multiError)
+
+	err := WithRepoLock(func() error {
+		for _, l := range locks {
+			nowLocked[l.Path] = true
+			writable := l.Owner.Name == config.CurrentCommitter()
+			if err := tools.SetFileWriteFlag(l.Path, writable); err != nil {
+				errs.Add(err)
+			}
+		}
+
+		for path := range previouslyLocked {
+			if nowLocked[path] {
+				continue
+			}
+			if err := tools.SetFileWriteFlag(path, false); err != nil {
+				errs.Add(err)
+			}
+		}
+
+		return errs.ErrorOrNil()
+	})
+
+	return nowLocked, err
+}
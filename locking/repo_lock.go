@@ -0,0 +1,45 @@
+package locking
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/github/git-lfs/config"
+)
+
+// DefaultRepoLockTimeout is how long WithRepoLock waits to acquire the
+// cross-process repository lock before giving up.
+const DefaultRepoLockTimeout = 30 * time.Second
+
+// repoLockPath returns the path of the lockfile used to arbitrate access to
+// lockable-state between concurrent git-lfs processes.
+func repoLockPath() string {
+	return filepath.Join(config.LocalWorkingDir, ".git", "lfs", "locks.lock")
+}
+
+// WithRepoLock runs fn while holding an exclusive, cross-process lock on the
+// repository's lockable state. Callers should hold it across any compound
+// operation that reads or writes lock state so that another process can't
+// observe or apply a partial update. Rather than block forever, it returns
+// an error if the lock isn't acquired within DefaultRepoLockTimeout.
+func WithRepoLock(fn func() error) error {
+	path := repoLockPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("locking: could not create directory for %q: %v", path, err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("locking: could not open lockfile %q: %v", path, err)
+	}
+	defer f.Close()
+
+	if err := lockFileTimeout(f, DefaultRepoLockTimeout); err != nil {
+		return err
+	}
+	defer unlockFile(f)
+
+	return fn()
+}
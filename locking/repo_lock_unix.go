@@ -0,0 +1,33 @@
+// +build !windows
+
+package locking
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// lockFileTimeout takes an exclusive, non-blocking flock() on f, retrying
+// until it succeeds or timeout elapses.
+func lockFileTimeout(f *os.File, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return nil
+		}
+		if err != syscall.EWOULDBLOCK {
+			return fmt.Errorf("locking: could not lock %q: %v", f.Name(), err)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("locking: timed out after %s waiting for lock on %q; is another git-lfs process running?", timeout, f.Name())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
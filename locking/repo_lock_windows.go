@@ -0,0 +1,36 @@
+// +build windows
+
+package locking
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFileTimeout takes an exclusive, non-blocking LockFileEx on f, retrying
+// until it succeeds or timeout elapses.
+func lockFileTimeout(f *os.File, timeout time.Duration) error {
+	ol := new(windows.Overlapped)
+	deadline := time.Now().Add(timeout)
+	for {
+		err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_FAIL_IMMEDIATELY|windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, ol)
+		if err == nil {
+			return nil
+		}
+		if err != windows.ERROR_LOCK_VIOLATION {
+			return fmt.Errorf("locking: could not lock %q: %v", f.Name(), err)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("locking: timed out after %s waiting for lock on %q; is another git-lfs process running?", timeout, f.Name())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func unlockFile(f *os.File) error {
+	ol := new(windows.Overlapped)
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, ol)
+}
@@ -0,0 +1,163 @@
+package locking
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rubyist/tracerx"
+
+	"github.com/github/git-lfs/config"
+	"github.com/github/git-lfs/tools"
+)
+
+// RemoteLockPollInterval is how often Watch asks the server for the current
+// lock listing, to pick up lock/unlock actions taken from another machine.
+const RemoteLockPollInterval = 5 * time.Second
+
+// remoteLockPoll is the result of a background listRemoteLocks call, sent
+// back to Watch's main loop over a channel so the network round trip never
+// blocks event handling.
+type remoteLockPoll struct {
+	locks []remoteLock
+	err   error
+}
+
+// Watch starts a long-running scan of the working tree rooted at
+// config.LocalWorkingDir, keeping lockable file write flags in sync as
+// files are created or renamed into place locally, and as locks change on
+// the server. It underlies `git lfs locks --watch` and only returns when
+// ctx is cancelled or it hits an error it can't recover from.
+//
+// Filesystem hiccups (a permission-denied subdirectory, a stat racing an
+// editor's atomic rename) and failed server polls are logged and skipped
+// rather than stopping the watcher, since it's meant to run indefinitely
+// alongside ordinary editor and IDE file churn.
+func Watch(ctx context.Context) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("locking: could not start filesystem watcher: %v", err)
+	}
+	defer fsw.Close()
+
+	root := config.LocalWorkingDir
+	if err := addWatchDirs(fsw, root); err != nil {
+		return err
+	}
+
+	poll := time.NewTicker(RemoteLockPollInterval)
+	defer poll.Stop()
+
+	polling := false
+	pollResults := make(chan remoteLockPoll, 1)
+	lockedPaths := make(map[string]bool)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case ev, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if err := handleWatchEvent(fsw, root, ev); err != nil {
+				tracerx.Printf("locking: watch: %v", err)
+			}
+
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			tracerx.Printf("locking: watch: filesystem watch error: %v", err)
+
+		case <-poll.C:
+			if polling {
+				// Previous poll hasn't come back yet; don't pile up requests
+				continue
+			}
+			polling = true
+			go func() {
+				locks, err := listRemoteLocks(ctx)
+				pollResults <- remoteLockPoll{locks: locks, err: err}
+			}()
+
+		case res := <-pollResults:
+			polling = false
+			if res.err != nil {
+				tracerx.Printf("locking: watch: %v", res.err)
+				continue
+			}
+			newLockedPaths, err := applyRemoteLocks(res.locks, lockedPaths)
+			lockedPaths = newLockedPaths
+			if err != nil {
+				tracerx.Printf("locking: watch: %v", err)
+			}
+		}
+	}
+}
+
+// addWatchDirs recursively registers dir and all its subdirectories with
+// fsw, so that fsnotify reports events for files created anywhere in the
+// tree, not just directly inside dir. A directory that can't be read or
+// watched (eg permission denied) is logged and skipped along with its
+// subtree, so the rest of the tree is still registered.
+func addWatchDirs(fsw *fsnotify.Watcher, dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			tracerx.Printf("locking: watch: skipping %q: %v", path, err)
+			return nil
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if err := fsw.Add(path); err != nil {
+			tracerx.Printf("locking: watch: skipping %q: %v", path, err)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// handleWatchEvent reacts to a single fsnotify event. Newly created
+// directories are added to the watch list so files created inside them are
+// seen too; newly created or renamed-into-place files that match a lockable
+// pattern have their write flag corrected immediately.
+func handleWatchEvent(fsw *fsnotify.Watcher, root string, ev fsnotify.Event) error {
+	if ev.Op&(fsnotify.Create|fsnotify.Rename) == 0 {
+		return nil
+	}
+
+	stat, err := os.Stat(ev.Name)
+	if err != nil {
+		// Already removed or renamed away again; nothing to fix up
+		return nil
+	}
+
+	if stat.IsDir() {
+		return addWatchDirs(fsw, ev.Name)
+	}
+
+	relpath, err := filepath.Rel(root, ev.Name)
+	if err != nil {
+		return err
+	}
+	if filepath.Separator == '\\' {
+		relpath = strings.Replace(relpath, "\\", "/", -1)
+	}
+
+	if !IsFileLockable(relpath) {
+		return nil
+	}
+
+	return WithRepoLock(func() error {
+		return tools.SetFileWriteFlag(relpath, IsFileLockedByCurrentCommitter(relpath))
+	})
+}